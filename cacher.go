@@ -7,7 +7,14 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb/dirhash"
+
+	"github.com/goproxy/goproxy/internal/lockedfile"
 )
 
 // ErrCacheNotFound is the error resulting if a path search failed to find a
@@ -52,12 +59,84 @@ type LocalCacher struct {
 	//
 	// Note that the `Root` must be a UNIX-style path.
 	Root string
+
+	nameMutexesMutex sync.Mutex
+	nameMutexes      map[string]*sync.RWMutex
 }
 
 // Get implements the `Cacher`.
+//
+// A `Cache` returned by `Get` remains valid to read even if the same name is
+// concurrently overwritten by a `Set`, whether from this process or another
+// one sharing the same `Root`: the shared lock taken below, together with
+// the atomic rename performed by `Set`, guarantees that the content seen by
+// a reader is always that of a single, complete `Set` call.
 func (c *LocalCacher) Get(ctx context.Context, name string) (Cache, error) {
-	file, err := os.Open(c.localName(name))
+	localName, err := c.localName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	nameMutex := c.nameMutex(localName)
+	nameMutex.RLock()
+
+	// Skip taking (and thereby creating) the sidecar lock file altogether
+	// on a clean miss, since `lockedfile.Open` would otherwise leave a
+	// permanent, never-cleaned-up "<localName>.lock" behind for every
+	// queried name that was never `Set` - which, on the miss-then-fetch
+	// path that dominates a public module proxy's traffic, leaks one
+	// inode per distinct miss for the life of the process.
+	if _, err := os.Stat(localName); err != nil {
+		nameMutex.RUnlock()
+
+		if os.IsNotExist(err) {
+			return nil, ErrCacheNotFound
+		}
+
+		return nil, err
+	}
+
+	lock, err := lockedfile.Open(localName)
+	if err != nil {
+		nameMutex.RUnlock()
+
+		if os.IsNotExist(err) {
+			return nil, ErrCacheNotFound
+		}
+
+		return nil, err
+	}
+
+	release := func() error {
+		err := lock.Close()
+		nameMutex.RUnlock()
+		return err
+	}
+
+	if strings.HasSuffix(name, ".zip") {
+		if err := verifyZipHash(localName); err != nil {
+			// Remove the stale files while still holding the shared
+			// lock, not after releasing it: releasing first would let
+			// a concurrent `Set` acquire the exclusive lock, write
+			// fresh content, and rename it into place, only for these
+			// removes to then delete that freshly-written file out
+			// from under it.
+			if err == ErrCacheNotFound {
+				os.Remove(localName)
+				os.Remove(localName + "hash")
+				os.Remove(lockedfile.LockPath(localName))
+			}
+
+			release()
+
+			return nil, err
+		}
+	}
+
+	file, err := os.Open(localName)
 	if err != nil {
+		release()
+
 		if os.IsNotExist(err) {
 			return nil, ErrCacheNotFound
 		}
@@ -67,6 +146,8 @@ func (c *LocalCacher) Get(ctx context.Context, name string) (Cache, error) {
 
 	fileInfo, err := file.Stat()
 	if err != nil {
+		file.Close()
+		release()
 		return nil, err
 	}
 
@@ -74,35 +155,194 @@ func (c *LocalCacher) Get(ctx context.Context, name string) (Cache, error) {
 		file:    file,
 		name:    name,
 		modTime: fileInfo.ModTime(),
+		release: release,
 	}, nil
 }
 
 // Set implements the `Cacher`.
+//
+// The r is streamed into a temporary file in the same directory as the
+// target and then atomically renamed into place, so that a concurrent `Get`
+// never observes a partially written file. If the name ends in ".zip", the
+// `h1:` hash of its content is additionally computed and written to a
+// sibling "<name>hash" file for later integrity verification by `Get`.
 func (c *LocalCacher) Set(ctx context.Context, name string, r io.Reader) error {
-	b, err := ioutil.ReadAll(r)
+	localName, err := c.localName(name)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(localName)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	nameMutex := c.nameMutex(localName)
+	nameMutex.Lock()
+	defer nameMutex.Unlock()
+
+	lock, err := lockedfile.Create(localName)
 	if err != nil {
 		return err
 	}
+	defer lock.Close()
 
-	localName := c.localName(name)
-	if err := os.MkdirAll(
-		filepath.Dir(localName),
-		os.ModePerm,
-	); err != nil {
+	tempFile, err := ioutil.TempFile(dir, ".goproxy-*")
+	if err != nil {
 		return err
 	}
+	tempName := tempFile.Name()
+	defer os.Remove(tempName)
 
-	return ioutil.WriteFile(localName, b, os.ModePerm)
+	if _, err := io.Copy(tempFile, r); err != nil {
+		tempFile.Close()
+		return err
+	}
+
+	if err := tempFile.Sync(); err != nil {
+		tempFile.Close()
+		return err
+	}
+
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tempName, os.ModePerm); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tempName, localName); err != nil {
+		return err
+	}
+
+	if strings.HasSuffix(name, ".zip") {
+		hash, err := dirhash.HashZip(localName, dirhash.DefaultHash)
+		if err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(
+			localName+"hash",
+			[]byte(hash),
+			os.ModePerm,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nameMutex returns the `sync.RWMutex` used to guard in-process access to
+// the localName, creating it if necessary. It does not reduce the number of
+// `lockedfile` calls made - a `flock(2)` lock is per open file description,
+// not per process, so same-process goroutines already serialize on it on
+// their own. What `nameMutex` adds is that a same-process `Set` can neither
+// run between `Get`'s existence check and its taking of the OS lock, nor be
+// left waiting on a sidecar lock file that `Get` removes along with a
+// corrupt zip, since `Set` waits on `nameMutex` before it opens the lock
+// file.
+func (c *LocalCacher) nameMutex(localName string) *sync.RWMutex {
+	c.nameMutexesMutex.Lock()
+	defer c.nameMutexesMutex.Unlock()
+
+	if c.nameMutexes == nil {
+		c.nameMutexes = map[string]*sync.RWMutex{}
+	}
+
+	nameMutex, ok := c.nameMutexes[localName]
+	if !ok {
+		nameMutex = &sync.RWMutex{}
+		c.nameMutexes[localName] = nameMutex
+	}
+
+	return nameMutex
+}
+
+// verifyZipHash verifies the content at localName against its sibling
+// "<localName>hash" file, in the same way `cmd/go/internal/modfetch` verifies
+// the `.ziphash` sidecar of a downloaded module zip. It returns nil if there
+// is no sibling hash file to verify against. It returns `ErrCacheNotFound` if
+// the sibling hash file exists but the content no longer matches it, so that
+// the corrupt cache is treated as missing and re-fetched upstream.
+func verifyZipHash(localName string) error {
+	wantHash, err := ioutil.ReadFile(localName + "hash")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	// Any failure to recompute the hash (including a missing or malformed
+	// zip) means the cache can no longer be trusted, so it is treated the
+	// same as a hash mismatch.
+	gotHash, err := dirhash.HashZip(localName, dirhash.DefaultHash)
+	if err != nil || gotHash != string(wantHash) {
+		return ErrCacheNotFound
+	}
+
+	return nil
 }
 
 // localName returns the local representation of the name.
-func (c *LocalCacher) localName(name string) string {
-	name = filepath.FromSlash(name)
+//
+// It applies the same "safe encoding" as the official module cache (see
+// `golang.org/x/mod/module.EscapePath`) to the module path portion of the
+// name, so that modules whose paths differ only in case (e.g.
+// "github.com/Foo/Bar" and "github.com/foo/bar") do not collide on
+// case-insensitive filesystems such as those used by default on macOS and
+// Windows.
+func (c *LocalCacher) localName(name string) (string, error) {
+	escapedName, err := escapeName(name)
+	if err != nil {
+		return "", err
+	}
+
+	escapedName = filepath.FromSlash(escapedName)
 	if c.Root != "" {
-		return filepath.Join(filepath.FromSlash(c.Root), name)
+		return filepath.Join(filepath.FromSlash(c.Root), escapedName), nil
 	}
 
-	return filepath.Join(os.TempDir(), name)
+	return filepath.Join(os.TempDir(), escapedName), nil
+}
+
+// escapeName escapes the module path portion of the name (the part before
+// the "/@v/" or "/@latest" marker) using `module.EscapePath`, leaving the
+// rest of the name (which is already lowercase per the module proxy
+// protocol) untouched.
+//
+// Names that do not carry a "/@v/" or "/@latest" marker are not shaped like
+// a module cache path, so they are returned unescaped: `module.EscapePath`
+// rejects anything that isn't a syntactically valid Go module path, and the
+// `Cacher` interface promises to accept any UNIX-style path, not just ones
+// shaped like the module cache.
+func escapeName(name string) (string, error) {
+	modulePath, rest := splitPathVersion(name)
+	if rest == "" {
+		return name, nil
+	}
+
+	escapedModulePath, err := module.EscapePath(modulePath)
+	if err != nil {
+		return "", err
+	}
+
+	return escapedModulePath + rest, nil
+}
+
+// splitPathVersion splits the name into its module path and the remaining
+// "/@v/..." or "/@latest" suffix, if any.
+func splitPathVersion(name string) (modulePath, rest string) {
+	for _, marker := range []string{"/@v/", "/@latest"} {
+		if i := strings.Index(name, marker); i >= 0 {
+			return name[:i], name[i:]
+		}
+	}
+
+	return name, ""
 }
 
 // localCache implements the `Cache`. It is the cache unit of the `LocalCacher`.
@@ -110,6 +350,7 @@ type localCache struct {
 	file    *os.File
 	name    string
 	modTime time.Time
+	release func() error
 }
 
 // Read implements the `Cache`.
@@ -124,7 +365,12 @@ func (lc *localCache) Seek(offset int64, whence int) (int64, error) {
 
 // Close implements the `Cache`.
 func (lc *localCache) Close() error {
-	return lc.file.Close()
+	err := lc.file.Close()
+	if releaseErr := lc.release(); err == nil {
+		err = releaseErr
+	}
+
+	return err
 }
 
 // Name implements the `Cache`.
@@ -135,4 +381,4 @@ func (lc *localCache) Name() string {
 // ModTime implements the `Cache`.
 func (lc *localCache) ModTime() time.Time {
 	return lc.modTime
-}
\ No newline at end of file
+}
@@ -0,0 +1,134 @@
+package cacher
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/goproxy/goproxy"
+)
+
+// ChainedCacher implements the `goproxy.Cacher` by consulting a list of
+// tiers in order, from fastest/cheapest to slowest/most-expensive.
+//
+// It must not be copied after first use.
+type ChainedCacher struct {
+	// Tiers are the `goproxy.Cacher` tiers consulted in order by `Get`.
+	//
+	// On a hit in a tier, that tier's content is written through to every
+	// earlier (faster) tier so that subsequent requests for the same name
+	// are served without falling through to the slower tiers.
+	Tiers []goproxy.Cacher
+
+	// NegativeTTL is the duration for which a `goproxy.ErrCacheNotFound`
+	// result is remembered, so that repeated lookups of a name known to
+	// be missing from every tier do not stampede the (often more
+	// expensive) later tiers. If `NegativeTTL` is zero, misses are not
+	// remembered.
+	NegativeTTL time.Duration
+
+	negativeMutex sync.Mutex
+	negative      map[string]time.Time
+}
+
+// Get implements the `goproxy.Cacher`.
+func (cc *ChainedCacher) Get(ctx context.Context, name string) (goproxy.Cache, error) {
+	if cc.missRecently(name) {
+		return nil, goproxy.ErrCacheNotFound
+	}
+
+	for i, tier := range cc.Tiers {
+		cache, err := tier.Get(ctx, name)
+		if err == goproxy.ErrCacheNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		b, err := ioutil.ReadAll(cache)
+		modTime := cache.ModTime()
+		closeErr := cache.Close()
+		if err != nil {
+			return nil, err
+		} else if closeErr != nil {
+			return nil, closeErr
+		}
+
+		for _, earlierTier := range cc.Tiers[:i] {
+			_ = earlierTier.Set(ctx, name, bytes.NewReader(b))
+		}
+
+		return &memoryCache{
+			name:    name,
+			modTime: modTime,
+			Reader:  bytes.NewReader(b),
+		}, nil
+	}
+
+	cc.recordMiss(name)
+
+	return nil, goproxy.ErrCacheNotFound
+}
+
+// Set implements the `goproxy.Cacher`. It fans the r out to every tier.
+func (cc *ChainedCacher) Set(ctx context.Context, name string, r io.Reader) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	for _, tier := range cc.Tiers {
+		if err := tier.Set(ctx, name, bytes.NewReader(b)); err != nil {
+			return err
+		}
+	}
+
+	cc.negativeMutex.Lock()
+	delete(cc.negative, name)
+	cc.negativeMutex.Unlock()
+
+	return nil
+}
+
+// missRecently reports whether the name was recorded as missing by
+// `recordMiss` within the `NegativeTTL`.
+func (cc *ChainedCacher) missRecently(name string) bool {
+	if cc.NegativeTTL <= 0 {
+		return false
+	}
+
+	cc.negativeMutex.Lock()
+	defer cc.negativeMutex.Unlock()
+
+	missedAt, ok := cc.negative[name]
+	if !ok {
+		return false
+	}
+
+	if time.Since(missedAt) > cc.NegativeTTL {
+		delete(cc.negative, name)
+		return false
+	}
+
+	return true
+}
+
+// recordMiss remembers that the name was missing from every tier, so that
+// `missRecently` can short-circuit lookups for the `NegativeTTL`.
+func (cc *ChainedCacher) recordMiss(name string) {
+	if cc.NegativeTTL <= 0 {
+		return
+	}
+
+	cc.negativeMutex.Lock()
+	defer cc.negativeMutex.Unlock()
+
+	if cc.negative == nil {
+		cc.negative = map[string]time.Time{}
+	}
+
+	cc.negative[name] = time.Now()
+}
@@ -0,0 +1,66 @@
+package cacher
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/goproxy/goproxy"
+	"github.com/goproxy/goproxy/cachertest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChainedCacher(t *testing.T) {
+	cachertest.Test(t, &ChainedCacher{Tiers: []goproxy.Cacher{&MemoryCacher{}, &MemoryCacher{}}})
+}
+
+func TestChainedCacherWriteThrough(t *testing.T) {
+	hot := &MemoryCacher{}
+	cold := &MemoryCacher{}
+	cc := &ChainedCacher{Tiers: []goproxy.Cacher{hot, cold}}
+
+	ctx := context.Background()
+
+	// Populate only the cold tier directly, as if it was already warm
+	// from a previous, unrelated fetch.
+	assert.NoError(t, cold.Set(ctx, "a", bytes.NewReader([]byte("a"))))
+
+	cache, err := cc.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.NoError(t, cache.Close())
+
+	// The hit in the cold tier must have been written through to hot.
+	hotCache, err := hot.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.NoError(t, hotCache.Close())
+}
+
+func TestChainedCacherNegativeTTL(t *testing.T) {
+	cc := &ChainedCacher{
+		Tiers:       []goproxy.Cacher{&MemoryCacher{}},
+		NegativeTTL: time.Hour,
+	}
+
+	ctx := context.Background()
+
+	_, err := cc.Get(ctx, "missing")
+	assert.Equal(t, goproxy.ErrCacheNotFound, err)
+	assert.True(t, cc.missRecently("missing"))
+
+	// Populating the tier after the miss was recorded must not be visible
+	// until the negative cache entry expires.
+	assert.NoError(t, cc.Tiers[0].Set(ctx, "missing", bytes.NewReader([]byte("now here"))))
+
+	_, err = cc.Get(ctx, "missing")
+	assert.Equal(t, goproxy.ErrCacheNotFound, err)
+
+	// A `Set` through the `ChainedCacher` itself must clear the negative
+	// cache entry.
+	assert.NoError(t, cc.Set(ctx, "missing", bytes.NewReader([]byte("now here"))))
+	assert.False(t, cc.missRecently("missing"))
+
+	cache, err := cc.Get(ctx, "missing")
+	assert.NoError(t, err)
+	assert.NoError(t, cache.Close())
+}
@@ -0,0 +1,171 @@
+package cacher
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/goproxy/goproxy"
+)
+
+// memcachedChunkSize is the maximum size, in bytes, of a single Memcached
+// item written by the `MemcachedCacher`. It is kept under the default 1 MiB
+// Memcached item size limit, which counts the key and item header as well
+// as the value.
+const memcachedChunkSize = 1<<20 - 1<<10
+
+// MemcachedCacher implements the `goproxy.Cacher` by using Memcached as the
+// underlying storage.
+//
+// Values larger than `memcachedChunkSize` (1 KiB under 1 MiB) are split
+// across multiple Memcached items, since
+// Memcached rejects items larger than its configured (by default 1 MiB)
+// `-I` limit.
+type MemcachedCacher struct {
+	// Client is the client used to talk to the Memcached server(s).
+	Client *memcache.Client
+
+	// KeyPrefix is prepended to every cache name to form the Memcached
+	// key, so that multiple applications can share a Memcached cluster
+	// without colliding.
+	KeyPrefix string
+
+	// Expiration is the expiration, in seconds, passed to Memcached for
+	// every cache entry set via `Set`. It follows the same convention as
+	// `memcache.Item.Expiration`: zero means never expire, and a value
+	// more than 30 days is treated as a UNIX timestamp.
+	Expiration int32
+}
+
+// memcachedManifest is stored under the unchunked key and describes how to
+// reassemble the chunks written for a cache entry.
+type memcachedManifest struct {
+	Chunks  int
+	ModTime int64
+}
+
+// key returns the Memcached key for the name.
+func (mc *MemcachedCacher) key(name string) string {
+	return mc.KeyPrefix + name
+}
+
+func chunkKey(key string, i int) string {
+	return fmt.Sprintf("%s#%d", key, i)
+}
+
+// Get implements the `goproxy.Cacher`.
+func (mc *MemcachedCacher) Get(ctx context.Context, name string) (goproxy.Cache, error) {
+	key := mc.key(name)
+
+	manifestItem, err := mc.Client.Get(key)
+	if err != nil {
+		if err == memcache.ErrCacheMiss {
+			return nil, goproxy.ErrCacheNotFound
+		}
+
+		return nil, err
+	}
+
+	manifest, err := decodeMemcachedManifest(manifestItem.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	for i := 0; i < manifest.Chunks; i++ {
+		chunkItem, err := mc.Client.Get(chunkKey(key, i))
+		if err != nil {
+			if err == memcache.ErrCacheMiss {
+				return nil, goproxy.ErrCacheNotFound
+			}
+
+			return nil, err
+		}
+
+		buf.Write(chunkItem.Value)
+	}
+
+	return &memoryCache{
+		name:    name,
+		modTime: time.Unix(0, manifest.ModTime),
+		Reader:  bytes.NewReader(buf.Bytes()),
+	}, nil
+}
+
+// Set implements the `goproxy.Cacher`.
+func (mc *MemcachedCacher) Set(ctx context.Context, name string, r io.Reader) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	key := mc.key(name)
+
+	chunks := 0
+	for offset := 0; offset < len(b) || (offset == 0 && len(b) == 0); offset += memcachedChunkSize {
+		end := offset + memcachedChunkSize
+		if end > len(b) {
+			end = len(b)
+		}
+
+		if err := mc.Client.Set(&memcache.Item{
+			Key:        chunkKey(key, chunks),
+			Value:      b[offset:end],
+			Expiration: mc.Expiration,
+		}); err != nil {
+			return err
+		}
+
+		chunks++
+	}
+
+	manifest, err := encodeMemcachedManifest(memcachedManifest{
+		Chunks:  chunks,
+		ModTime: time.Now().UnixNano(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return mc.Client.Set(&memcache.Item{
+		Key:        key,
+		Value:      manifest,
+		Expiration: mc.Expiration,
+	})
+}
+
+// encodeMemcachedManifest encodes the m into a compact binary form suitable
+// for storage in a single Memcached item.
+func encodeMemcachedManifest(m memcachedManifest) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := binary.Write(buf, binary.BigEndian, int64(m.Chunks)); err != nil {
+		return nil, err
+	}
+
+	if err := binary.Write(buf, binary.BigEndian, m.ModTime); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decodeMemcachedManifest is the inverse of `encodeMemcachedManifest`.
+func decodeMemcachedManifest(b []byte) (memcachedManifest, error) {
+	buf := bytes.NewReader(b)
+
+	var chunks, modTime int64
+	if err := binary.Read(buf, binary.BigEndian, &chunks); err != nil {
+		return memcachedManifest{}, err
+	}
+
+	if err := binary.Read(buf, binary.BigEndian, &modTime); err != nil {
+		return memcachedManifest{}, err
+	}
+
+	return memcachedManifest{Chunks: int(chunks), ModTime: modTime}, nil
+}
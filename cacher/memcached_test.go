@@ -0,0 +1,51 @@
+package cacher
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/goproxy/goproxy/cachertest"
+)
+
+func TestMemcachedCacher(t *testing.T) {
+	client := memcache.New("127.0.0.1:11211")
+
+	if err := client.Set(&memcache.Item{Key: "goproxy-test-ping", Value: []byte("1")}); err != nil {
+		t.Skipf("no Memcached server available: %v", err)
+	}
+
+	cachertest.Test(t, &MemcachedCacher{Client: client, KeyPrefix: "goproxy-test:"})
+}
+
+func TestMemcachedCacherChunking(t *testing.T) {
+	client := memcache.New("127.0.0.1:11211")
+	if err := client.Set(&memcache.Item{Key: "goproxy-test-ping", Value: []byte("1")}); err != nil {
+		t.Skipf("no Memcached server available: %v", err)
+	}
+
+	mc := &MemcachedCacher{Client: client, KeyPrefix: "goproxy-test-chunk:"}
+
+	content := bytes.Repeat([]byte("x"), memcachedChunkSize+1)
+
+	ctx := context.Background()
+	if err := mc.Set(ctx, "big", bytes.NewReader(content)); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := mc.Get(ctx, "big")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+
+	got := make([]byte, len(content))
+	if _, err := cache.Read(got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, content) {
+		t.Fatal("chunked content round-trip mismatch")
+	}
+}
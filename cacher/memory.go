@@ -0,0 +1,145 @@
+// Package cacher provides additional `goproxy.Cacher` implementations for
+// use when the local disk used by `goproxy.LocalCacher` is not available or
+// not shared, e.g. when running multiple `goproxy.Goproxy` instances behind
+// a load balancer.
+package cacher
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/goproxy/goproxy"
+)
+
+// MemoryCacher implements the `goproxy.Cacher` by using an in-process,
+// bounded LRU cache.
+//
+// It must not be copied after first use.
+type MemoryCacher struct {
+	// MaxBytes is the maximum total size, in bytes, of the cache entries
+	// held by the `MemoryCacher`. If `MaxBytes` is zero, the size of the
+	// `MemoryCacher` is unbounded.
+	MaxBytes int64
+
+	// TTL is the duration after which a cache entry set via `Set` is
+	// considered expired. If `TTL` is zero, cache entries never expire.
+	TTL time.Duration
+
+	once      sync.Once
+	mutex     sync.Mutex
+	ll        *list.List
+	items     map[string]*list.Element
+	totalSize int64
+}
+
+// memoryCacheEntry is the value held by an element of the `MemoryCacher.ll`.
+type memoryCacheEntry struct {
+	name    string
+	content []byte
+	modTime time.Time
+}
+
+// init initializes the internal state of the mc. It must be called while
+// the mc.mutex is held.
+func (mc *MemoryCacher) init() {
+	mc.once.Do(func() {
+		mc.ll = list.New()
+		mc.items = map[string]*list.Element{}
+	})
+}
+
+// Get implements the `goproxy.Cacher`.
+func (mc *MemoryCacher) Get(ctx context.Context, name string) (goproxy.Cache, error) {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+
+	mc.init()
+
+	elem, ok := mc.items[name]
+	if !ok {
+		return nil, goproxy.ErrCacheNotFound
+	}
+
+	entry := elem.Value.(*memoryCacheEntry)
+	if mc.TTL > 0 && time.Since(entry.modTime) > mc.TTL {
+		mc.removeElement(elem)
+		return nil, goproxy.ErrCacheNotFound
+	}
+
+	mc.ll.MoveToFront(elem)
+
+	return &memoryCache{
+		name:    entry.name,
+		modTime: entry.modTime,
+		Reader:  bytes.NewReader(entry.content),
+	}, nil
+}
+
+// Set implements the `goproxy.Cacher`.
+func (mc *MemoryCacher) Set(ctx context.Context, name string, r io.Reader) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+
+	mc.init()
+
+	if elem, ok := mc.items[name]; ok {
+		mc.removeElement(elem)
+	}
+
+	entry := &memoryCacheEntry{
+		name:    name,
+		content: b,
+		modTime: time.Now(),
+	}
+	mc.items[name] = mc.ll.PushFront(entry)
+	mc.totalSize += int64(len(b))
+
+	for mc.MaxBytes > 0 && mc.totalSize > mc.MaxBytes && mc.ll.Len() > 0 {
+		mc.removeElement(mc.ll.Back())
+	}
+
+	return nil
+}
+
+// removeElement removes the elem from the mc. It must be called while the
+// mc.mutex is held.
+func (mc *MemoryCacher) removeElement(elem *list.Element) {
+	entry := elem.Value.(*memoryCacheEntry)
+	mc.ll.Remove(elem)
+	delete(mc.items, entry.name)
+	mc.totalSize -= int64(len(entry.content))
+}
+
+// memoryCache implements the `goproxy.Cache`. It is the cache unit of the
+// `MemoryCacher`.
+type memoryCache struct {
+	*bytes.Reader
+
+	name    string
+	modTime time.Time
+}
+
+// Close implements the `goproxy.Cache`.
+func (mc *memoryCache) Close() error {
+	return nil
+}
+
+// Name implements the `goproxy.Cache`.
+func (mc *memoryCache) Name() string {
+	return mc.name
+}
+
+// ModTime implements the `goproxy.Cache`.
+func (mc *memoryCache) ModTime() time.Time {
+	return mc.modTime
+}
@@ -0,0 +1,50 @@
+package cacher
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/goproxy/goproxy"
+	"github.com/goproxy/goproxy/cachertest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCacher(t *testing.T) {
+	cachertest.Test(t, &MemoryCacher{})
+}
+
+func TestMemoryCacherMaxBytes(t *testing.T) {
+	mc := &MemoryCacher{MaxBytes: 4}
+	ctx := context.Background()
+
+	assert.NoError(t, mc.Set(ctx, "a", bytes.NewReader([]byte("aaaa"))))
+	assert.NoError(t, mc.Set(ctx, "b", bytes.NewReader([]byte("bbbb"))))
+
+	// "a" must have been evicted to make room for "b" within MaxBytes.
+	_, err := mc.Get(ctx, "a")
+	assert.Equal(t, goproxy.ErrCacheNotFound, err)
+
+	cache, err := mc.Get(ctx, "b")
+	assert.NoError(t, err)
+	assert.NoError(t, cache.Close())
+
+	assert.True(t, mc.totalSize <= mc.MaxBytes)
+}
+
+func TestMemoryCacherTTL(t *testing.T) {
+	mc := &MemoryCacher{TTL: 10 * time.Millisecond}
+	ctx := context.Background()
+
+	assert.NoError(t, mc.Set(ctx, "a", bytes.NewReader([]byte("a"))))
+
+	cache, err := mc.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.NoError(t, cache.Close())
+
+	time.Sleep(20 * time.Millisecond)
+
+	_, err = mc.Get(ctx, "a")
+	assert.Equal(t, goproxy.ErrCacheNotFound, err)
+}
@@ -0,0 +1,93 @@
+package cacher
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/goproxy/goproxy"
+)
+
+// RedisCacher implements the `goproxy.Cacher` by using Redis as the
+// underlying storage.
+type RedisCacher struct {
+	// Client is the client used to talk to the Redis server.
+	Client *redis.Client
+
+	// KeyPrefix is prepended to every cache name to form the Redis key, so
+	// that multiple applications can share a single Redis server/database
+	// without colliding.
+	KeyPrefix string
+
+	// TTL is the expiration passed to Redis for every cache entry set via
+	// `Set`. If `TTL` is zero, cache entries never expire.
+	TTL time.Duration
+}
+
+// modTimeKey returns the key of the sidecar value that holds the
+// modification time of the cache entry stored at key.
+//
+// Redis has no notion of "last write time" (`OBJECT IDLETIME` only reports
+// time since last access, which is bumped by reads), so the modification
+// time is stored alongside the content instead.
+func modTimeKey(key string) string {
+	return key + ":mtime"
+}
+
+// key returns the Redis key for the name.
+func (rc *RedisCacher) key(name string) string {
+	return rc.KeyPrefix + name
+}
+
+// Get implements the `goproxy.Cacher`.
+func (rc *RedisCacher) Get(ctx context.Context, name string) (goproxy.Cache, error) {
+	key := rc.key(name)
+	client := rc.Client.WithContext(ctx)
+
+	b, err := client.Get(key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, goproxy.ErrCacheNotFound
+		}
+
+		return nil, err
+	}
+
+	modTime := time.Now()
+	if mtns, err := client.Get(modTimeKey(key)).Int64(); err == nil {
+		modTime = time.Unix(0, mtns)
+	}
+
+	return &memoryCache{
+		name:    name,
+		modTime: modTime,
+		Reader:  bytes.NewReader(b),
+	}, nil
+}
+
+// Set implements the `goproxy.Cacher`.
+func (rc *RedisCacher) Set(ctx context.Context, name string, r io.Reader) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	key := rc.key(name)
+	client := rc.Client.WithContext(ctx)
+
+	pipeline := client.Pipeline()
+	pipeline.Set(key, b, rc.TTL)
+	pipeline.Set(
+		modTimeKey(key),
+		strconv.FormatInt(time.Now().UnixNano(), 10),
+		rc.TTL,
+	)
+
+	_, err = pipeline.Exec()
+
+	return err
+}
@@ -0,0 +1,19 @@
+package cacher
+
+import (
+	"testing"
+
+	"github.com/go-redis/redis"
+	"github.com/goproxy/goproxy/cachertest"
+)
+
+func TestRedisCacher(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:6379"})
+	defer client.Close()
+
+	if err := client.Ping().Err(); err != nil {
+		t.Skipf("no Redis server available: %v", err)
+	}
+
+	cachertest.Test(t, &RedisCacher{Client: client, KeyPrefix: "goproxy-test:"})
+}
@@ -0,0 +1,70 @@
+package cacher
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/goproxy/goproxy"
+	"golang.org/x/sync/singleflight"
+)
+
+// Singleflight wraps a `goproxy.Cacher` so that concurrent `Get` calls for
+// the same name are deduplicated into a single call to the underlying
+// `Cacher`, collapsing a thundering herd of clients asking for the same
+// (possibly missing) name into one fetch.
+type Singleflight struct {
+	// Cacher is the underlying `goproxy.Cacher`.
+	Cacher goproxy.Cacher
+
+	group singleflight.Group
+}
+
+// singleflightResult is the deduplicated result of a `Singleflight.Get`
+// call. The underlying `goproxy.Cache` content is read into memory so that
+// every waiter of the shared call gets its own independent `goproxy.Cache`.
+type singleflightResult struct {
+	name    string
+	content []byte
+	modTime time.Time
+}
+
+// Get implements the `goproxy.Cacher`.
+func (s *Singleflight) Get(ctx context.Context, name string) (goproxy.Cache, error) {
+	v, err, _ := s.group.Do(name, func() (interface{}, error) {
+		cache, err := s.Cacher.Get(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		defer cache.Close()
+
+		b, err := ioutil.ReadAll(cache)
+		if err != nil {
+			return nil, err
+		}
+
+		return &singleflightResult{
+			name:    cache.Name(),
+			content: b,
+			modTime: cache.ModTime(),
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := v.(*singleflightResult)
+
+	return &memoryCache{
+		name:    result.name,
+		modTime: result.modTime,
+		Reader:  bytes.NewReader(result.content),
+	}, nil
+}
+
+// Set implements the `goproxy.Cacher`.
+func (s *Singleflight) Set(ctx context.Context, name string, r io.Reader) error {
+	return s.Cacher.Set(ctx, name, r)
+}
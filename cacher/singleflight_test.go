@@ -0,0 +1,65 @@
+package cacher
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goproxy/goproxy"
+	"github.com/goproxy/goproxy/cachertest"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingCacher counts the number of `Get` calls that reach the underlying
+// `MemoryCacher`, and sleeps for a bit in each to widen the window in which
+// concurrent `Get` calls can be observed by a `Singleflight`.
+type countingCacher struct {
+	MemoryCacher
+
+	gets int32
+}
+
+func (cc *countingCacher) Get(ctx context.Context, name string) (goproxy.Cache, error) {
+	atomic.AddInt32(&cc.gets, 1)
+	time.Sleep(20 * time.Millisecond)
+	return cc.MemoryCacher.Get(ctx, name)
+}
+
+func TestSingleflight(t *testing.T) {
+	cachertest.Test(t, &Singleflight{Cacher: &MemoryCacher{}})
+}
+
+func TestSingleflightDeduplicatesConcurrentGets(t *testing.T) {
+	underlying := &countingCacher{}
+	ctx := context.Background()
+	assert.NoError(t, underlying.Set(ctx, "a", bytes.NewReader([]byte("a"))))
+
+	sf := &Singleflight{Cacher: underlying}
+
+	const n = 16
+	var ready, start sync.WaitGroup
+	ready.Add(n)
+	start.Add(1)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			ready.Done()
+			start.Wait()
+			cache, err := sf.Get(ctx, "a")
+			assert.NoError(t, err)
+			assert.NoError(t, cache.Close())
+		}()
+	}
+	ready.Wait()
+	start.Done()
+	wg.Wait()
+
+	// Every waiter got a result, but the underlying `Cacher` should have
+	// been hit fewer than n times.
+	assert.True(t, atomic.LoadInt32(&underlying.gets) < n)
+}
@@ -0,0 +1,12 @@
+package goproxy_test
+
+import (
+	"testing"
+
+	"github.com/goproxy/goproxy"
+	"github.com/goproxy/goproxy/cachertest"
+)
+
+func TestLocalCacherConformance(t *testing.T) {
+	cachertest.Test(t, &goproxy.LocalCacher{Root: t.TempDir()})
+}
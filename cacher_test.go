@@ -0,0 +1,149 @@
+package goproxy
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestZip builds a minimal module zip containing a single file.
+func newTestZip(t *testing.T, content string) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	fw, err := zw.Create("example.com/foo@v1.0.0/go.mod")
+	assert.NoError(t, err)
+	_, err = fw.Write([]byte(content))
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestLocalCacherCaseInsensitiveRoundTrip(t *testing.T) {
+	root, err := ioutil.TempDir("", "goproxy")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	lc := &LocalCacher{Root: root}
+
+	upperName := "example.com/Foo/Bar/@v/v1.0.0.info"
+	lowerName := "example.com/foo/bar/@v/v1.0.0.info"
+
+	assert.NoError(t, lc.Set(context.Background(), upperName, strings.NewReader("upper")))
+	assert.NoError(t, lc.Set(context.Background(), lowerName, strings.NewReader("lower")))
+
+	upperCache, err := lc.Get(context.Background(), upperName)
+	assert.NoError(t, err)
+	defer upperCache.Close()
+	upperContent, err := ioutil.ReadAll(upperCache)
+	assert.NoError(t, err)
+	assert.Equal(t, "upper", string(upperContent))
+	assert.Equal(t, upperName, upperCache.Name())
+
+	lowerCache, err := lc.Get(context.Background(), lowerName)
+	assert.NoError(t, err)
+	defer lowerCache.Close()
+	lowerContent, err := ioutil.ReadAll(lowerCache)
+	assert.NoError(t, err)
+	assert.Equal(t, "lower", string(lowerContent))
+	assert.Equal(t, lowerName, lowerCache.Name())
+
+	escapedLocalName, err := lc.localName(upperName)
+	assert.NoError(t, err)
+	assert.Contains(t, escapedLocalName, "!foo")
+	assert.Contains(t, escapedLocalName, "!bar")
+	assert.NotEqual(t, filepath.Join(root, filepath.FromSlash(upperName)), escapedLocalName)
+}
+
+func TestLocalCacherGetNotFoundLeavesNoLockFile(t *testing.T) {
+	root, err := ioutil.TempDir("", "goproxy")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	lc := &LocalCacher{Root: root}
+
+	name := "example.com/foo/@v/v1.0.0.info"
+	_, err = lc.Get(context.Background(), name)
+	assert.Equal(t, ErrCacheNotFound, err)
+
+	localName, err := lc.localName(name)
+	assert.NoError(t, err)
+	_, err = os.Stat(localName + ".lock")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLocalCacherZipIntegrity(t *testing.T) {
+	root, err := ioutil.TempDir("", "goproxy")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	lc := &LocalCacher{Root: root}
+
+	name := "example.com/foo/@v/v1.0.0.zip"
+	assert.NoError(t, lc.Set(
+		context.Background(),
+		name,
+		bytes.NewReader(newTestZip(t, "module example.com/foo")),
+	))
+
+	localName, err := lc.localName(name)
+	assert.NoError(t, err)
+	assert.FileExists(t, localName+"hash")
+
+	cache, err := lc.Get(context.Background(), name)
+	assert.NoError(t, err)
+	assert.NoError(t, cache.Close())
+
+	// Corrupt the zip in place and verify that `Get` detects the mismatch,
+	// reports `ErrCacheNotFound`, and removes the corrupt files.
+	assert.NoError(t, ioutil.WriteFile(localName, []byte("corrupt"), os.ModePerm))
+
+	_, err = lc.Get(context.Background(), name)
+	assert.Equal(t, ErrCacheNotFound, err)
+	_, err = os.Stat(localName)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(localName + "hash")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLocalCacherConcurrentSetAndGet(t *testing.T) {
+	root, err := ioutil.TempDir("", "goproxy")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	lc := &LocalCacher{Root: root}
+
+	name := "example.com/foo/@v/v1.0.0.zip"
+
+	const n = 8
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			content := fmt.Sprintf("module example.com/foo // writer %d", i)
+			assert.NoError(t, lc.Set(
+				context.Background(),
+				name,
+				bytes.NewReader(newTestZip(t, content)),
+			))
+		}(i)
+	}
+	wg.Wait()
+
+	// Whichever writer finished last, the zip and its sidecar hash file
+	// must be mutually consistent.
+	cache, err := lc.Get(context.Background(), name)
+	assert.NoError(t, err)
+	defer cache.Close()
+	_, err = ioutil.ReadAll(cache)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,82 @@
+// Package cachertest provides a conformance test suite for implementations
+// of the `goproxy.Cacher` interface.
+//
+// Any third-party `goproxy.Cacher` implementation can call `Test` from its
+// own tests to verify that it honors the `goproxy.Cacher` contract.
+package cachertest
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/goproxy/goproxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test runs a conformance test suite against the c.
+//
+// The c must be empty with respect to the names used by this test suite
+// ("cachertest/a", "cachertest/b" and "cachertest/not-found") since it does
+// not clean up after itself.
+func Test(t *testing.T, c goproxy.Cacher) {
+	t.Run("GetNotFound", func(t *testing.T) {
+		testGetNotFound(t, c)
+	})
+
+	t.Run("SetAndGet", func(t *testing.T) {
+		testSetAndGet(t, c)
+	})
+
+	t.Run("Overwrite", func(t *testing.T) {
+		testOverwrite(t, c)
+	})
+}
+
+func testGetNotFound(t *testing.T, c goproxy.Cacher) {
+	_, err := c.Get(context.Background(), "cachertest/not-found")
+	assert.Equal(t, goproxy.ErrCacheNotFound, err)
+}
+
+func testSetAndGet(t *testing.T, c goproxy.Cacher) {
+	const name = "cachertest/a"
+	const content = "a"
+
+	assert.NoError(t, c.Set(context.Background(), name, bytes.NewReader([]byte(content))))
+
+	cache, err := c.Get(context.Background(), name)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	assert.Equal(t, name, cache.Name())
+	assert.False(t, cache.ModTime().IsZero())
+
+	b, err := ioutil.ReadAll(cache)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(b))
+
+	// The returned `Cache` must also be seekable.
+	_, err = cache.Seek(0, 0)
+	assert.NoError(t, err)
+
+	b, err = ioutil.ReadAll(cache)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(b))
+}
+
+func testOverwrite(t *testing.T, c goproxy.Cacher) {
+	const name = "cachertest/b"
+
+	assert.NoError(t, c.Set(context.Background(), name, bytes.NewReader([]byte("b1"))))
+	assert.NoError(t, c.Set(context.Background(), name, bytes.NewReader([]byte("b2"))))
+
+	cache, err := c.Get(context.Background(), name)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	b, err := ioutil.ReadAll(cache)
+	assert.NoError(t, err)
+	assert.Equal(t, "b2", string(b))
+}
@@ -0,0 +1,59 @@
+// Package lockedfile provides advisory, cross-process file locking modeled
+// on the approach used by `cmd/go/internal/lockedfile`: an advisory OS-level
+// lock is taken on a sidecar "<name>.lock" file beside the file actually
+// being protected, rather than on the file itself, so that the protected
+// file can still be freely read, written, and atomically renamed by whoever
+// holds the lock.
+package lockedfile
+
+import (
+	"github.com/gofrs/flock"
+)
+
+// File represents an OS-level advisory lock held on the sidecar
+// "<name>.lock" file beside a name passed to `Open` or `Create`. The lock is
+// released when `Close` is called.
+type File struct {
+	flock *flock.Flock
+}
+
+// LockPath returns the path of the sidecar lock file used to guard name.
+//
+// Callers that remove name (e.g. because it was found to be corrupt) may
+// want to also remove LockPath(name) to avoid leaving a stray lock file
+// behind once no `Open`/`Create` is holding it.
+func LockPath(name string) string {
+	return name + ".lock"
+}
+
+// Open takes a shared (read) lock on the sidecar lock file of name. It
+// blocks until the lock is acquired.
+//
+// Open always creates the sidecar lock file if it does not already exist,
+// even if name itself does not exist. Callers that only want to lock an
+// existing name should check for its existence first and skip calling Open
+// on a clean miss, to avoid leaving a lock file behind forever.
+func Open(name string) (*File, error) {
+	fl := flock.New(name + ".lock")
+	if err := fl.RLock(); err != nil {
+		return nil, err
+	}
+
+	return &File{flock: fl}, nil
+}
+
+// Create takes an exclusive (write) lock on the sidecar lock file of name.
+// It blocks until the lock is acquired.
+func Create(name string) (*File, error) {
+	fl := flock.New(name + ".lock")
+	if err := fl.Lock(); err != nil {
+		return nil, err
+	}
+
+	return &File{flock: fl}, nil
+}
+
+// Close releases the lock.
+func (f *File) Close() error {
+	return f.flock.Unlock()
+}
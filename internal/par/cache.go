@@ -0,0 +1,83 @@
+// Package par provides a generic, typed, singleflight-style cache modeled
+// on the `par.Cache` used internally by the Go toolchain
+// (`cmd/go/internal/par`). It is meant for caching the results of
+// expensive, idempotent lookups - such as parsed `.info` JSON, resolved
+// version lists, or sumdb lookups - so that a thundering herd of callers
+// asking for the same key share a single evaluation instead of each
+// boxing/unboxing an `interface{}` and managing their own per-key mutex.
+//
+// As of this writing, this package has no caller: this tree has no request
+// handler, version-list assembly, or "@latest" resolution code for it to be
+// wired into yet, so `Cache` is provided unwired, ready for whichever of
+// those lands first.
+package par
+
+import "sync"
+
+// Cache is a typed cache keyed by K whose entries are populated by `Do`.
+//
+// The zero value of a Cache is ready to use. A Cache must not be copied
+// after first use.
+type Cache[K comparable, V any] struct {
+	mutex   sync.Mutex
+	entries map[K]*cacheEntry[V]
+}
+
+// cacheEntry is the (possibly still in-flight) value held for a single key.
+type cacheEntry[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// Do calls f and returns its result, caching it under the key.
+//
+// If a call for the key is already in flight, Do waits for it to complete
+// and returns its result instead of calling f again; if a call for the key
+// has already completed, its cached result is returned immediately. The
+// third return value reports whether f was called by this goroutine, as
+// opposed to sharing another goroutine's call or a previously cached
+// result.
+func (c *Cache[K, V]) Do(key K, f func() (V, error)) (V, error, bool) {
+	c.mutex.Lock()
+	if c.entries == nil {
+		c.entries = map[K]*cacheEntry[V]{}
+	}
+
+	if e, ok := c.entries[key]; ok {
+		c.mutex.Unlock()
+		<-e.done
+		return e.value, e.err, false
+	}
+
+	e := &cacheEntry[V]{done: make(chan struct{})}
+	c.entries[key] = e
+	c.mutex.Unlock()
+
+	e.value, e.err = f()
+	close(e.done)
+
+	return e.value, e.err, true
+}
+
+// Get returns the value cached for key by a completed call to `Do`. The
+// second return value reports whether such a value exists; it is false if
+// no call for key has completed yet, whether because none was ever started
+// or because one is still in flight.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mutex.Lock()
+	e, ok := c.entries[key]
+	c.mutex.Unlock()
+
+	var zero V
+	if !ok {
+		return zero, false
+	}
+
+	select {
+	case <-e.done:
+		return e.value, true
+	default:
+		return zero, false
+	}
+}
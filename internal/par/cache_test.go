@@ -0,0 +1,99 @@
+package par
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCacheDoCachesResult(t *testing.T) {
+	var c Cache[string, int]
+	var calls int32
+
+	for i := 0; i < 3; i++ {
+		v, err, _ := c.Do("a", func() (int, error) {
+			atomic.AddInt32(&calls, 1)
+			return 42, nil
+		})
+		if err != nil || v != 42 {
+			t.Fatalf("Do() = %v, %v, want 42, nil", v, err)
+		}
+	}
+
+	if calls != 1 {
+		t.Fatalf("f was called %d times, want 1", calls)
+	}
+}
+
+func TestCacheDoDeduplicatesConcurrentCalls(t *testing.T) {
+	var c Cache[string, int]
+	var calls int32
+
+	var ready, start sync.WaitGroup
+	const n = 16
+	ready.Add(n)
+	start.Add(1)
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	results := make([]int, n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			ready.Done()
+			start.Wait()
+			v, _, _ := c.Do("a", func() (int, error) {
+				atomic.AddInt32(&calls, 1)
+				return 7, nil
+			})
+			results[i] = v
+		}(i)
+	}
+	ready.Wait()
+	start.Done()
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("f was called %d times, want 1", calls)
+	}
+
+	for i, v := range results {
+		if v != 7 {
+			t.Fatalf("results[%d] = %d, want 7", i, v)
+		}
+	}
+}
+
+func TestCacheDoCachesError(t *testing.T) {
+	var c Cache[string, int]
+	wantErr := errors.New("boom")
+
+	_, err, _ := c.Do("a", func() (int, error) { return 0, wantErr })
+	if err != wantErr {
+		t.Fatalf("err = %v, want %v", err, wantErr)
+	}
+
+	_, err, fresh := c.Do("a", func() (int, error) {
+		t.Fatal("f must not be called again for a cached error")
+		return 0, nil
+	})
+	if err != wantErr || fresh {
+		t.Fatalf("Do() = _, %v, %v, want %v, false", err, fresh, wantErr)
+	}
+}
+
+func TestCacheGet(t *testing.T) {
+	var c Cache[string, int]
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("Get() of an unset key must report ok = false")
+	}
+
+	c.Do("a", func() (int, error) { return 1, nil })
+
+	v, ok := c.Get("a")
+	if !ok || v != 1 {
+		t.Fatalf("Get() = %v, %v, want 1, true", v, ok)
+	}
+}